@@ -0,0 +1,342 @@
+// Command cfreq-tui is an interactive fuzzy-finder over the command-frequency
+// history store, in the spirit of fzf. It's meant to be wired up as a
+// shell widget (e.g. bound to Ctrl-R) that prints the chosen command to
+// stdout, or run standalone with -exec to run it directly.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/term"
+
+	"github.com/EFulmer/command-frequency/store"
+)
+
+const (
+	keyCtrlC = 3
+	keyCtrlO = 15
+	keyCtrlR = 18
+	keyEnter = 13
+	keyEsc   = 27
+	keyBS    = 127
+)
+
+// filterMode selects which field of an entry incremental filtering matches
+// against.
+type filterMode int
+
+const (
+	filterRaw filterMode = iota // full raw command line
+	filterCmd                   // base command only
+)
+
+type model struct {
+	all      []store.Entry // candidate set, subject to the cwd-only toggle
+	cwdOnly  bool
+	cwd      string
+	mode     filterMode
+	query    []rune
+	selected int
+
+	rows int // terminal rows available for the candidate list
+	cols int
+}
+
+// candidate pairs an entry with its fuzzy-match span for the current query,
+// used only during ranking.
+type candidate struct {
+	entry store.Entry
+	span  int
+}
+
+func main() {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error finding home dir: %v\n", err)
+		os.Exit(1)
+	}
+
+	dbPath := flag.String("db", filepath.Join(home, ".local", "share", "command-frequency", "history.db"), "path to the SQLite history database")
+	limit := flag.Int("limit", 50000, "maximum number of history rows to load (0 means unlimited)")
+	execSelected := flag.Bool("exec", false, "exec the selected command instead of printing it to stdout")
+	cwdOnly := flag.Bool("cwd", false, "start restricted to commands run in the current directory (only entries imported from atuin carry a cwd; it's a no-op on zsh/bash/fish history)")
+	flag.Parse()
+
+	db, err := store.Open(*dbPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error opening store: %v\n", err)
+		os.Exit(1)
+	}
+	defer db.Close()
+
+	entries, err := db.Search(store.SearchOptions{Limit: *limit})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading history: %v\n", err)
+		os.Exit(1)
+	}
+
+	if !anyHaveCwd(entries) {
+		fmt.Fprintln(os.Stderr, "Warning: no loaded entries have a recorded directory (only atuin imports do); -cwd and ^O will filter out everything")
+	}
+
+	wd, _ := os.Getwd()
+	m := &model{all: entries, cwdOnly: *cwdOnly, cwd: wd}
+
+	selected, err := m.run()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if selected == nil {
+		os.Exit(130) // conventional exit code for a cancelled selection
+	}
+
+	if *execSelected {
+		cmd := exec.Command("sh", "-c", selected.Parsed.Raw)
+		cmd.Stdin = os.Stdin
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		if err := cmd.Run(); err != nil {
+			os.Exit(1)
+		}
+		return
+	}
+
+	fmt.Println(selected.Parsed.Raw)
+}
+
+// run drives the interactive loop and returns the selected entry, or nil if
+// the user cancelled.
+func (m *model) run() (*store.Entry, error) {
+	fd := int(os.Stdin.Fd())
+	oldState, err := term.MakeRaw(fd)
+	if err != nil {
+		return nil, fmt.Errorf("entering raw mode: %w", err)
+	}
+	defer term.Restore(fd, oldState)
+
+	cols, rows, err := term.GetSize(fd)
+	if err != nil {
+		cols, rows = 80, 24
+	}
+	m.cols, m.rows = cols, rows
+
+	buf := make([]byte, 16)
+	for {
+		m.render()
+
+		n, err := os.Stdin.Read(buf)
+		if err != nil {
+			return nil, err
+		}
+
+		ranked := m.ranked()
+
+		for i := 0; i < n; i++ {
+			b := buf[i]
+			switch b {
+			case keyCtrlC:
+				return nil, nil
+			case keyEnter:
+				if m.selected < len(ranked) {
+					e := ranked[m.selected].entry
+					return &e, nil
+				}
+				return nil, nil
+			case keyBS:
+				if len(m.query) > 0 {
+					m.query = m.query[:len(m.query)-1]
+					m.selected = 0
+				}
+			case keyCtrlR:
+				if m.mode == filterRaw {
+					m.mode = filterCmd
+				} else {
+					m.mode = filterRaw
+				}
+				m.selected = 0
+			case keyCtrlO:
+				m.cwdOnly = !m.cwdOnly
+				m.selected = 0
+			case keyEsc: // bare ESC cancels; ESC [ A/B is an arrow key
+				if i+2 < n && buf[i+1] == '[' {
+					switch buf[i+2] {
+					case 'A': // up
+						if m.selected > 0 {
+							m.selected--
+						}
+					case 'B': // down
+						if m.selected < len(ranked)-1 {
+							m.selected++
+						}
+					}
+					i += 2
+				} else {
+					return nil, nil
+				}
+			default:
+				if b >= 0x20 && b < 0x7f {
+					m.query = append(m.query, rune(b))
+					m.selected = 0
+				}
+			}
+		}
+	}
+}
+
+// anyHaveCwd reports whether any entry carries a recorded directory, so
+// callers can warn up front that the cwd-only filter (-cwd, ^O) is a no-op
+// otherwise.
+func anyHaveCwd(entries []store.Entry) bool {
+	for _, e := range entries {
+		if e.Cwd != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// ranked returns the candidate set filtered by the cwd-only toggle and
+// sorted by fuzzy-match quality: shorter matched spans first, then shorter
+// overall strings, so tight and concise matches float to the top.
+func (m *model) ranked() []candidate {
+	query := string(m.query)
+
+	var pool []store.Entry
+	if m.cwdOnly {
+		for _, e := range m.all {
+			if e.Cwd == m.cwd {
+				pool = append(pool, e)
+			}
+		}
+	} else {
+		pool = m.all
+	}
+
+	var out []candidate
+	for _, e := range pool {
+		target := e.Parsed.Raw
+		if m.mode == filterCmd {
+			target = e.Parsed.Cmd
+		}
+		ok, span := fuzzyMatch(query, target)
+		if !ok {
+			continue
+		}
+		out = append(out, candidate{entry: e, span: span})
+	}
+
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].span != out[j].span {
+			return out[i].span < out[j].span
+		}
+		li, lj := len(out[i].entry.Parsed.Raw), len(out[j].entry.Parsed.Raw)
+		if li != lj {
+			return li < lj
+		}
+		return out[i].entry.Timestamp.After(out[j].entry.Timestamp)
+	})
+
+	return out
+}
+
+// fuzzyMatch reports whether query's runes occur in target in order
+// (case-insensitive), and if so the length of the shortest substring of
+// target that contains them.
+func fuzzyMatch(query, target string) (ok bool, span int) {
+	if query == "" {
+		return true, len(target)
+	}
+	q := []rune(strings.ToLower(query))
+	t := []rune(strings.ToLower(target))
+
+	bestSpan := -1
+	for start := 0; start < len(t); start++ {
+		if t[start] != q[0] {
+			continue
+		}
+		pos := start
+		qi := 1
+		for qi < len(q) && pos+1 < len(t) {
+			pos++
+			if t[pos] == q[qi] {
+				qi++
+			}
+		}
+		if qi == len(q) {
+			if s := pos - start + 1; bestSpan == -1 || s < bestSpan {
+				bestSpan = s
+			}
+		}
+	}
+
+	if bestSpan == -1 {
+		return false, 0
+	}
+	return true, bestSpan
+}
+
+func (m *model) render() {
+	ranked := m.ranked()
+	if m.selected >= len(ranked) {
+		m.selected = 0
+	}
+
+	var b strings.Builder
+	b.WriteString("\x1b[2J\x1b[H") // clear screen, cursor home
+
+	mode := "raw"
+	if m.mode == filterCmd {
+		mode = "cmd"
+	}
+	cwdFlag := ""
+	if m.cwdOnly {
+		cwdFlag = " [cwd]"
+	}
+	fmt.Fprintf(&b, "> %s\x1b[K\r\n", string(m.query))
+	fmt.Fprintf(&b, "%d matches (mode=%s%s, ^R mode, ^O cwd, ^C cancel)\x1b[K\r\n", len(ranked), mode, cwdFlag)
+
+	listRows := m.rows - 6
+	if listRows < 1 {
+		listRows = 1
+	}
+	for i := 0; i < listRows; i++ {
+		if i >= len(ranked) {
+			b.WriteString("\x1b[K\r\n")
+			continue
+		}
+		line := ranked[i].entry.Parsed.Raw
+		if i == m.selected {
+			fmt.Fprintf(&b, "> %s\x1b[K\r\n", truncate(line, m.cols-2))
+		} else {
+			fmt.Fprintf(&b, "  %s\x1b[K\r\n", truncate(line, m.cols-2))
+		}
+	}
+
+	b.WriteString("\x1b[K\r\n")
+	if m.selected < len(ranked) {
+		e := ranked[m.selected].entry
+		fmt.Fprintf(&b, "-- %s  dur=%ds  argv=%v\x1b[K\r\n",
+			e.Timestamp.Format("2006-01-02 15:04:05"), e.Duration, append([]string{e.Parsed.Cmd}, e.Parsed.Args...))
+	} else {
+		b.WriteString("\x1b[K\r\n")
+	}
+
+	os.Stdout.WriteString(b.String())
+}
+
+func truncate(s string, n int) string {
+	if n <= 0 || len(s) <= n {
+		return s
+	}
+	if n <= 1 {
+		return s[:n]
+	}
+	return s[:n-1] + "…"
+}
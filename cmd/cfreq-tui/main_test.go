@@ -0,0 +1,106 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/EFulmer/command-frequency/store"
+)
+
+func TestAnyHaveCwd(t *testing.T) {
+	if anyHaveCwd(nil) {
+		t.Error("anyHaveCwd(nil) = true, want false")
+	}
+	if anyHaveCwd([]store.Entry{{}, {}}) {
+		t.Error("anyHaveCwd of entries with no cwd = true, want false")
+	}
+	if !anyHaveCwd([]store.Entry{{}, {Cwd: "/home/e"}}) {
+		t.Error("anyHaveCwd of entries including one with a cwd = false, want true")
+	}
+}
+
+func TestFuzzyMatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		target   string
+		wantOK   bool
+		wantSpan int
+	}{
+		{"empty query matches everything", "", "git status", true, len("git status")},
+		{"exact prefix", "git", "git status", true, 3},
+		{"in-order subsequence spans the gap between matches", "gst", "git status", true, 6},
+		{"case insensitive", "GIT", "git status", true, 3},
+		{"no match", "xyz", "git status", false, 0},
+		{"shortest span preferred among repeats", "ab", "xaybxab", true, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, span := fuzzyMatch(tt.query, tt.target)
+			if ok != tt.wantOK {
+				t.Fatalf("fuzzyMatch(%q, %q) ok = %v, want %v", tt.query, tt.target, ok, tt.wantOK)
+			}
+			if ok && span != tt.wantSpan {
+				t.Errorf("fuzzyMatch(%q, %q) span = %d, want %d", tt.query, tt.target, span, tt.wantSpan)
+			}
+		})
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		s    string
+		n    int
+		want string
+	}{
+		{"short", 10, "short"},
+		{"exactly10!", 10, "exactly10!"},
+		{"this is long", 6, "this …"},
+		{"anything", 0, "anything"},
+	}
+
+	for _, tt := range tests {
+		got := truncate(tt.s, tt.n)
+		if got != tt.want {
+			t.Errorf("truncate(%q, %d) = %q, want %q", tt.s, tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestModelRanked(t *testing.T) {
+	now := time.Unix(1690000000, 0)
+	m := &model{
+		all: []store.Entry{
+			{Timestamp: now, Cwd: "/home/e", Parsed: store.ParsedCommand{Raw: "git status", Cmd: "git"}},
+			{Timestamp: now, Cwd: "/tmp", Parsed: store.ParsedCommand{Raw: "git commit -m wip", Cmd: "git"}},
+			{Timestamp: now, Cwd: "/home/e", Parsed: store.ParsedCommand{Raw: "ls -la", Cmd: "ls"}},
+		},
+	}
+
+	t.Run("shorter match ranks first", func(t *testing.T) {
+		m.query = []rune("git")
+		ranked := m.ranked()
+		if len(ranked) != 2 {
+			t.Fatalf("got %d candidates, want 2", len(ranked))
+		}
+		if ranked[0].entry.Parsed.Raw != "git status" {
+			t.Errorf("ranked[0] = %q, want tighter match \"git status\" first", ranked[0].entry.Parsed.Raw)
+		}
+	})
+
+	t.Run("cwd-only filters to the invocation directory", func(t *testing.T) {
+		m.query = nil
+		m.cwdOnly = true
+		m.cwd = "/home/e"
+		ranked := m.ranked()
+		if len(ranked) != 2 {
+			t.Fatalf("got %d candidates, want 2", len(ranked))
+		}
+		for _, c := range ranked {
+			if c.entry.Cwd != "/home/e" {
+				t.Errorf("ranked entry with cwd %q leaked through cwd-only filter", c.entry.Cwd)
+			}
+		}
+	})
+}
@@ -1,233 +1,235 @@
 package main
 
 import (
-	"bufio"
+	"flag"
 	"fmt"
 	"os"
 	"path/filepath"
-	"strconv"
 	"strings"
 	"time"
-	"unicode"
-)
-
-// ParsedCommand holds a split command and its arguments
-type ParsedCommand struct {
-	Raw  string   // original full command string
-	Cmd  string   // the base command (argv[0])
-	Args []string // arguments (argv[1:])
-}
-
-// Entry represents a single zsh history entry
-type Entry struct {
-	Timestamp time.Time
-	Duration  int
-	Parsed    ParsedCommand
-}
 
-// shellSplit splits a shell command string respecting quotes and escapes.
-// Handles: single quotes, double quotes, backslash escapes, and unquoted tokens.
-func shellSplit(s string) []string {
-	var tokens []string
-	var current strings.Builder
-	inSingle := false
-	inDouble := false
-	i := 0
-
-	for i < len(s) {
-		c := rune(s[i])
-
-		switch {
-		case inSingle:
-			if c == '\'' {
-				inSingle = false
-			} else {
-				current.WriteRune(c)
-			}
-			i++
-
-		case inDouble:
-			if c == '\\' && i+1 < len(s) {
-				next := rune(s[i+1])
-				// Only these chars are escapable inside double quotes
-				if next == '"' || next == '\\' || next == '$' || next == '`' || next == '\n' {
-					current.WriteRune(next)
-					i += 2
-				} else {
-					current.WriteRune(c)
-					i++
-				}
-			} else if c == '"' {
-				inDouble = false
-				i++
-			} else {
-				current.WriteRune(c)
-				i++
-			}
-
-		case c == '\\' && i+1 < len(s):
-			// Backslash escape outside quotes
-			current.WriteRune(rune(s[i+1]))
-			i += 2
+	"golang.org/x/term"
 
-		case c == '\'':
-			inSingle = true
-			i++
+	"github.com/EFulmer/command-frequency/pattern"
+	"github.com/EFulmer/command-frequency/store"
+)
 
-		case c == '"':
-			inDouble = true
-			i++
+const ansiRed = "\x1b[31m"
+const ansiReset = "\x1b[0m"
 
-		case unicode.IsSpace(c):
-			if current.Len() > 0 {
-				tokens = append(tokens, current.String())
-				current.Reset()
-			}
-			i++
+func defaultDBPath(home string) string {
+	return filepath.Join(home, ".local", "share", "command-frequency", "history.db")
+}
 
-		default:
-			current.WriteRune(c)
-			i++
-		}
+func main() {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error finding home dir: %v\n", err)
+		os.Exit(1)
 	}
 
-	if current.Len() > 0 {
-		tokens = append(tokens, current.String())
+	dbPath := flag.String("db", defaultDBPath(home), "path to the SQLite history database")
+	histPath := flag.String("history", filepath.Join(home, ".zsh_history"), "path to the shell history file to ingest")
+	format := flag.String("format", "auto", "history file format: auto, zsh, bash, fish, or atuin")
+	sidecarPath := flag.String("sidecar", "", "path to a timestamp/exit-code sidecar file to correlate with -history")
+	query := flag.String("q", "", "filter commands by substring, prefix (-prefix), or regex (-regex)")
+	prefix := flag.Bool("prefix", false, "treat -q as a base-command prefix match")
+	regex := flag.Bool("regex", false, "treat -q as a regular expression match")
+	today := flag.Bool("today", false, "restrict results to commands run today")
+	since := flag.String("since", "", "restrict results to commands run at or after this time (RFC3339 or 2006-01-02)")
+	until := flag.String("until", "", "restrict results to commands run at or before this time (RFC3339 or 2006-01-02)")
+	cwd := flag.Bool("cwd", false, "restrict results to commands run in the current directory (only entries imported from atuin carry a cwd; it's a no-op on zsh/bash/fish history)")
+	limit := flag.Int("limit", 0, "maximum number of rows to return (0 means unlimited)")
+	onlyFailed := flag.Bool("only-failed", false, "show only commands that exited non-zero")
+	onlySuccess := flag.Bool("only-success", false, "show only commands that exited zero")
+	splitStatus := flag.Bool("split-status", false, "report Top 10 Commands separately for successes and failures")
+	groupBy := flag.String("group-by", "", "report Top 10 values of <template>.<field> instead of Top 10 Commands, e.g. git.subcmd or ssh.host")
+	flag.Parse()
+
+	if err := checkExclusiveFlags(*onlyFailed, *onlySuccess); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
 	}
 
-	return tokens
-}
-
-func parseCommand(raw string) ParsedCommand {
-	// Trim leading whitespace and common shell prefixes like `sudo`, `env VAR=val`, etc.
-	tokens := shellSplit(strings.TrimSpace(raw))
-	if len(tokens) == 0 {
-		return ParsedCommand{Raw: raw}
+	if err := os.MkdirAll(filepath.Dir(*dbPath), 0o755); err != nil {
+		fmt.Fprintf(os.Stderr, "Error preparing db directory: %v\n", err)
+		os.Exit(1)
 	}
 
-	// Skip past leading env var assignments (e.g. FOO=bar cmd args)
-	start := 0
-	for start < len(tokens) && strings.Contains(tokens[start], "=") && !strings.HasPrefix(tokens[start], "-") {
-		start++
+	db, err := store.Open(*dbPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error opening store: %v\n", err)
+		os.Exit(1)
 	}
-	if start >= len(tokens) {
-		// Entire command was env vars
-		return ParsedCommand{Raw: raw, Cmd: tokens[0]}
+	defer db.Close()
+
+	histFormat := store.Format(*format)
+	if histFormat == "auto" {
+		histFormat, err = store.DetectFormat(*histPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error detecting history format: %v\n", err)
+			os.Exit(1)
+		}
 	}
-
-	return ParsedCommand{
-		Raw:  raw,
-		Cmd:  tokens[start],
-		Args: tokens[start+1:],
+	reader, err := store.ReaderFor(histFormat)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
 	}
-}
 
-func parseHistory(path string) ([]Entry, error) {
-	f, err := os.Open(path)
+	entries, err := reader.Read(*histPath)
 	if err != nil {
-		return nil, err
-	}
-	defer f.Close()
-
-	var entries []Entry
-	scanner := bufio.NewScanner(f)
-	scanner.Buffer(make([]byte, 1024*1024), 1024*1024)
-
-	var currentCmd strings.Builder
-
-	flushEntry := func(line string) {
-		var raw string
-
-		if strings.HasPrefix(line, ": ") {
-			parts := strings.SplitN(line, ";", 2)
-			if len(parts) == 2 {
-				meta := strings.TrimPrefix(parts[0], ": ")
-				metaParts := strings.SplitN(meta, ":", 2)
-				if len(metaParts) == 2 {
-					ts, err1 := strconv.ParseInt(strings.TrimSpace(metaParts[0]), 10, 64)
-					dur, err2 := strconv.Atoi(strings.TrimSpace(metaParts[1]))
-					if err1 == nil && err2 == nil {
-						raw = parts[1]
-						entries = append(entries, Entry{
-							Timestamp: time.Unix(ts, 0),
-							Duration:  dur,
-							Parsed:    parseCommand(raw),
-						})
-						return
-					}
-				}
-			}
-		}
+		fmt.Fprintf(os.Stderr, "Error parsing history: %v\n", err)
+		os.Exit(1)
+	}
 
-		raw = line
-		if raw != "" {
-			entries = append(entries, Entry{Parsed: parseCommand(raw)})
+	if *sidecarPath != "" {
+		codes, err := store.ParseExitCodeSidecar(*sidecarPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error parsing exit-code sidecar: %v\n", err)
+			os.Exit(1)
 		}
+		store.ApplyExitCodes(entries, codes)
 	}
 
-	for scanner.Scan() {
-		line := scanner.Text()
+	inserted, err := db.Ingest(entries)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error ingesting history: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Ingested %d new entries (%d parsed)\n\n", inserted, len(entries))
+
+	opts := store.SearchOptions{Query: *query, Limit: *limit, OnlyFailed: *onlyFailed, OnlySuccess: *onlySuccess}
+	switch {
+	case *prefix:
+		opts.Mode = store.MatchPrefix
+	case *regex:
+		opts.Mode = store.MatchRegex
+	default:
+		opts.Mode = store.MatchSubstring
+	}
 
-		if strings.HasSuffix(line, "\\") {
-			currentCmd.WriteString(strings.TrimSuffix(line, "\\"))
-			currentCmd.WriteString("\n")
-			continue
+	if *today {
+		now := time.Now()
+		opts.Since = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	}
+	if *since != "" {
+		t, err := parseTimeArg(*since)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error parsing -since: %v\n", err)
+			os.Exit(1)
 		}
-
-		if currentCmd.Len() > 0 {
-			currentCmd.WriteString(line)
-			flushEntry(currentCmd.String())
-			currentCmd.Reset()
-		} else {
-			flushEntry(line)
+		opts.Since = t
+	}
+	if *until != "" {
+		t, err := parseTimeArg(*until)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error parsing -until: %v\n", err)
+			os.Exit(1)
 		}
+		opts.Until = t
 	}
-
-	if currentCmd.Len() > 0 {
-		flushEntry(currentCmd.String())
+	if *cwd {
+		if histFormat != store.FormatAtuin {
+			fmt.Fprintf(os.Stderr, "Warning: -cwd only matches entries with a recorded directory, which today only atuin imports have; expect this to filter out everything from %s history\n", histFormat)
+		}
+		wd, err := os.Getwd()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error finding working dir: %v\n", err)
+			os.Exit(1)
+		}
+		opts.Cwd = wd
 	}
 
-	return entries, scanner.Err()
-}
-
-func main() {
-	home, err := os.UserHomeDir()
+	results, err := db.Search(opts)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error finding home dir: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Error searching history: %v\n", err)
 		os.Exit(1)
 	}
 
-	histPath := filepath.Join(home, ".zsh_history")
-	entries, err := parseHistory(histPath)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error parsing history: %v\n", err)
-		os.Exit(1)
+	useColor := term.IsTerminal(int(os.Stdout.Fd())) && os.Getenv("NO_COLOR") == ""
+
+	fmt.Printf("=== %d Matching Entries ===\n", len(results))
+	for _, e := range results {
+		line := fmt.Sprintf("[%s] cmd=%q args=%v", e.Timestamp.Format("2006-01-02 15:04:05"), e.Parsed.Cmd, e.Parsed.Args)
+		if useColor && e.ExitCode != 0 {
+			line = ansiRed + line + ansiReset
+		}
+		fmt.Println(line)
 	}
 
-	fmt.Printf("Parsed %d history entries\n\n", len(entries))
+	if *groupBy != "" {
+		tmplName, field, ok := strings.Cut(*groupBy, ".")
+		if !ok {
+			fmt.Fprintf(os.Stderr, "Error: -group-by must be <template>.<field>, e.g. git.subcmd\n")
+			os.Exit(1)
+		}
+		tmpl, ok := pattern.Builtins[tmplName]
+		if !ok {
+			fmt.Fprintf(os.Stderr, "Error: no built-in pattern named %q\n", tmplName)
+			os.Exit(1)
+		}
+		fmt.Printf("\n=== Top 10 %s ===\n", *groupBy)
+		printTopCounts(groupByField(results, tmpl, field))
+		return
+	}
 
-	// Example 1: print last 10 entries with split args
-	fmt.Println("=== Last 10 Entries ===")
-	start := len(entries) - 10
-	if start < 0 {
-		start = 0
+	if *splitStatus {
+		fmt.Println("\n=== Top 10 Commands (successes) ===")
+		printTopCounts(commandCounts(filterByExitCode(results, false)))
+		fmt.Println("\n=== Top 10 Commands (failures) ===")
+		printTopCounts(commandCounts(filterByExitCode(results, true)))
+	} else {
+		fmt.Println("\n=== Top 10 Commands ===")
+		printTopCounts(commandCounts(results))
 	}
-	for _, e := range entries[start:] {
-		ts := ""
-		if !e.Timestamp.IsZero() {
-			ts = fmt.Sprintf("[%s] ", e.Timestamp.Format("2006-01-02 15:04:05"))
+}
+
+// filterByExitCode returns the entries whose ExitCode is non-zero (failed)
+// or zero (succeeded).
+func filterByExitCode(entries []store.Entry, failed bool) []store.Entry {
+	var out []store.Entry
+	for _, e := range entries {
+		if (e.ExitCode != 0) == failed {
+			out = append(out, e)
 		}
-		fmt.Printf("%scmd=%q args=%v\n", ts, e.Parsed.Cmd, e.Parsed.Args)
 	}
+	return out
+}
 
-	// Example 2: top 10 most-used base commands
-	fmt.Println("\n=== Top 10 Commands ===")
+// commandCounts tallies base commands among entries. Each entry is counted
+// once regardless of whether it previously appeared as a failure elsewhere
+// in the result set, so a command that failed and later succeeded
+// contributes to both counts rather than being collapsed.
+func commandCounts(entries []store.Entry) map[string]int {
 	counts := make(map[string]int)
 	for _, e := range entries {
 		if e.Parsed.Cmd != "" {
 			counts[e.Parsed.Cmd]++
 		}
 	}
+	return counts
+}
+
+// groupByField tallies the values of field extracted from entries matching
+// tmpl. Entries that don't match tmpl, or that match but lack field, are
+// skipped rather than aborting the whole report.
+func groupByField(entries []store.Entry, tmpl *pattern.Template, field string) map[string]int {
+	counts := make(map[string]int)
+	for _, e := range entries {
+		fields, ok := tmpl.Match(e)
+		if !ok {
+			continue
+		}
+		if v, ok := fields[field]; ok && v != "" {
+			counts[v]++
+		}
+	}
+	return counts
+}
+
+// printTopCounts prints the 10 highest-count keys in counts.
+func printTopCounts(counts map[string]int) {
 	type kv struct {
 		Key   string
 		Value int
@@ -248,3 +250,20 @@ func main() {
 		fmt.Printf("  %3d  %s\n", sorted[i].Value, sorted[i].Key)
 	}
 }
+
+// checkExclusiveFlags rejects -only-failed and -only-success being set
+// together, since they'd otherwise silently combine into an
+// always-empty AND of the two.
+func checkExclusiveFlags(onlyFailed, onlySuccess bool) error {
+	if onlyFailed && onlySuccess {
+		return fmt.Errorf("-only-failed and -only-success are mutually exclusive")
+	}
+	return nil
+}
+
+func parseTimeArg(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	return time.ParseInLocation("2006-01-02", s, time.Local)
+}
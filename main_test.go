@@ -0,0 +1,98 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/EFulmer/command-frequency/pattern"
+	"github.com/EFulmer/command-frequency/store"
+)
+
+func TestFilterByExitCode(t *testing.T) {
+	entries := []store.Entry{
+		{Parsed: store.ParsedCommand{Cmd: "ls"}, ExitCode: 0},
+		{Parsed: store.ParsedCommand{Cmd: "grep"}, ExitCode: 1},
+		{Parsed: store.ParsedCommand{Cmd: "git"}, ExitCode: 0},
+	}
+
+	failed := filterByExitCode(entries, true)
+	if len(failed) != 1 || failed[0].Parsed.Cmd != "grep" {
+		t.Errorf("filterByExitCode(true) = %#v", failed)
+	}
+
+	succeeded := filterByExitCode(entries, false)
+	if len(succeeded) != 2 {
+		t.Errorf("filterByExitCode(false) = %#v, want 2 entries", succeeded)
+	}
+}
+
+func TestCommandCounts(t *testing.T) {
+	entries := []store.Entry{
+		{Parsed: store.ParsedCommand{Cmd: "git"}, ExitCode: 1},
+		{Parsed: store.ParsedCommand{Cmd: "git"}, ExitCode: 0},
+		{Parsed: store.ParsedCommand{Cmd: "ls"}},
+		{Parsed: store.ParsedCommand{Cmd: ""}},
+	}
+
+	got := commandCounts(entries)
+	want := map[string]int{"git": 2, "ls": 1}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("commandCounts = %v, want %v", got, want)
+	}
+}
+
+func TestGroupByField(t *testing.T) {
+	tmpl := pattern.MustCompile("git {subcmd} {*args}")
+	entries := []store.Entry{
+		{Parsed: store.ParsedCommand{Cmd: "git", Args: []string{"status"}}},
+		{Parsed: store.ParsedCommand{Cmd: "git", Args: []string{"status"}}},
+		{Parsed: store.ParsedCommand{Cmd: "git", Args: []string{"commit", "-m", "wip"}}},
+		{Parsed: store.ParsedCommand{Cmd: "ls"}}, // doesn't match the template
+	}
+
+	got := groupByField(entries, tmpl, "subcmd")
+	want := map[string]int{"status": 2, "commit": 1}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("groupByField = %v, want %v", got, want)
+	}
+}
+
+func TestParseTimeArg(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want time.Time
+	}{
+		{"RFC3339", "2026-07-29T10:00:00Z", time.Date(2026, 7, 29, 10, 0, 0, 0, time.UTC)},
+		{"date only", "2026-07-29", time.Date(2026, 7, 29, 0, 0, 0, 0, time.Local)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseTimeArg(tt.in)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("parseTimeArg(%q) = %v, want %v", tt.in, got, tt.want)
+			}
+		})
+	}
+
+	if _, err := parseTimeArg("not a time"); err == nil {
+		t.Error("parseTimeArg(\"not a time\") returned nil error, want error")
+	}
+}
+
+func TestCheckExclusiveFlags(t *testing.T) {
+	if err := checkExclusiveFlags(false, false); err != nil {
+		t.Errorf("checkExclusiveFlags(false, false) = %v, want nil", err)
+	}
+	if err := checkExclusiveFlags(true, false); err != nil {
+		t.Errorf("checkExclusiveFlags(true, false) = %v, want nil", err)
+	}
+	if err := checkExclusiveFlags(true, true); err == nil {
+		t.Error("checkExclusiveFlags(true, true) returned nil error, want error")
+	}
+}
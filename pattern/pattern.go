@@ -0,0 +1,158 @@
+// Package pattern lets callers register scanf-style templates (e.g.
+// "git {subcmd} {*args}") and extract named fields out of a history
+// entry's already-split argv, so command-specific analytics don't need
+// their own ad-hoc argv parsing.
+package pattern
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/EFulmer/command-frequency/store"
+)
+
+// Template is a compiled scanf-style pattern. Patterns are whitespace
+// separated; each word is either a literal, a field capture "{name}", or
+// (only as the final word) a rest capture "{*name}" that soaks up every
+// remaining argv token joined by spaces.
+type Template struct {
+	raw      string
+	segments []segment
+}
+
+type segment struct {
+	re       *regexp.Regexp // nil for a rest segment
+	names    []string
+	isRest   bool
+	restName string
+}
+
+// Compile parses a template string into a Template.
+func Compile(tmpl string) (*Template, error) {
+	words := strings.Fields(tmpl)
+	if len(words) == 0 {
+		return nil, fmt.Errorf("pattern: empty template")
+	}
+
+	t := &Template{raw: tmpl}
+	for i, w := range words {
+		if strings.HasPrefix(w, "{*") && strings.HasSuffix(w, "}") {
+			if i != len(words)-1 {
+				return nil, fmt.Errorf("pattern: rest capture %q must be the final word in %q", w, tmpl)
+			}
+			t.segments = append(t.segments, segment{isRest: true, restName: w[2 : len(w)-1]})
+			continue
+		}
+		re, names, err := compileSegment(w)
+		if err != nil {
+			return nil, fmt.Errorf("pattern: %q: %w", tmpl, err)
+		}
+		t.segments = append(t.segments, segment{re: re, names: names})
+	}
+
+	return t, nil
+}
+
+// MustCompile is like Compile but panics on error; intended for package-level
+// template tables built at init time.
+func MustCompile(tmpl string) *Template {
+	t, err := Compile(tmpl)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// compileSegment turns one whitespace-delimited template word into a regex
+// anchored to a single argv token, with one capture group per {field}.
+// All but the last field capture non-greedily so that literal delimiters
+// (like the "@" in "{user}@{host}") split the token as expected; the last
+// field is greedy so it absorbs the remainder of the token.
+func compileSegment(word string) (*regexp.Regexp, []string, error) {
+	var b strings.Builder
+	var names []string
+
+	b.WriteString("^")
+	i := 0
+	for i < len(word) {
+		if word[i] == '{' {
+			end := strings.IndexByte(word[i:], '}')
+			if end < 0 {
+				return nil, nil, fmt.Errorf("unterminated field in %q", word)
+			}
+			name := word[i+1 : i+end]
+			if name == "" {
+				return nil, nil, fmt.Errorf("empty field name in %q", word)
+			}
+			names = append(names, name)
+			b.WriteString("(.*?)")
+			i += end + 1
+		} else {
+			start := i
+			for i < len(word) && word[i] != '{' {
+				i++
+			}
+			b.WriteString(regexp.QuoteMeta(word[start:i]))
+		}
+	}
+	b.WriteString("$")
+
+	pattern := b.String()
+	if idx := strings.LastIndex(pattern, "(.*?)"); idx >= 0 {
+		pattern = pattern[:idx] + "(.*)" + pattern[idx+len("(.*?)"):]
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, nil, err
+	}
+	return re, names, nil
+}
+
+// Match extracts named fields from e's argv (Parsed.Cmd followed by
+// Parsed.Args) if it matches the template. Every extracted value is returned
+// as the raw substring matched by its field, with no attempt made to parse
+// it as any more specific type; callers that want e.g. an int do their own
+// conversion on the returned string.
+func (t *Template) Match(e store.Entry) (map[string]string, bool) {
+	argv := make([]string, 0, len(e.Parsed.Args)+1)
+	argv = append(argv, e.Parsed.Cmd)
+	argv = append(argv, e.Parsed.Args...)
+
+	fields := make(map[string]string)
+	tok := 0
+	for _, seg := range t.segments {
+		if seg.isRest {
+			fields[seg.restName] = strings.Join(argv[tok:], " ")
+			tok = len(argv)
+			continue
+		}
+		if tok >= len(argv) {
+			return nil, false
+		}
+		m := seg.re.FindStringSubmatch(argv[tok])
+		if m == nil {
+			return nil, false
+		}
+		for i, name := range seg.names {
+			fields[name] = m[i+1]
+		}
+		tok++
+	}
+
+	if tok != len(argv) {
+		return nil, false
+	}
+	return fields, true
+}
+
+// Builtins holds templates for commonly analyzed commands, keyed by the
+// name used in the CLI's -group-by <template>.<field> flag.
+var Builtins = map[string]*Template{
+	"git":     MustCompile("git {subcmd} {*args}"),
+	"docker":  MustCompile("docker {subcmd} {*args}"),
+	"kubectl": MustCompile("kubectl {subcmd} {*args}"),
+	"ssh":     MustCompile("ssh {user}@{host} {*rest}"),
+	"curl":    MustCompile("curl {*rest}"),
+}
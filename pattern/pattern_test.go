@@ -0,0 +1,143 @@
+package pattern
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/EFulmer/command-frequency/store"
+)
+
+func entry(raw string) store.Entry {
+	parts := splitForTest(raw)
+	e := store.Entry{}
+	e.Parsed.Raw = raw
+	if len(parts) > 0 {
+		e.Parsed.Cmd = parts[0]
+		e.Parsed.Args = parts[1:]
+	}
+	return e
+}
+
+// splitForTest is a minimal whitespace splitter; pattern doesn't need to
+// replicate store's quoting rules to exercise Compile/Match.
+func splitForTest(s string) []string {
+	var out []string
+	var cur []byte
+	flush := func() {
+		if len(cur) > 0 {
+			out = append(out, string(cur))
+			cur = nil
+		}
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] == ' ' {
+			flush()
+			continue
+		}
+		cur = append(cur, s[i])
+	}
+	flush()
+	return out
+}
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		name   string
+		tmpl   string
+		raw    string
+		want   map[string]string
+		wantOK bool
+	}{
+		{
+			name:   "literal plus rest capture",
+			tmpl:   "git {subcmd} {*args}",
+			raw:    "git commit -m wip",
+			want:   map[string]string{"subcmd": "commit", "args": "-m wip"},
+			wantOK: true,
+		},
+		{
+			name:   "rest capture with nothing left",
+			tmpl:   "git {subcmd} {*args}",
+			raw:    "git status",
+			want:   map[string]string{"subcmd": "status", "args": ""},
+			wantOK: true,
+		},
+		{
+			name:   "delimiter splits a single token",
+			tmpl:   "ssh {user}@{host} {*rest}",
+			raw:    "ssh alice@example.com -p 2222",
+			want:   map[string]string{"user": "alice", "host": "example.com", "rest": "-p 2222"},
+			wantOK: true,
+		},
+		{
+			name:   "no delimiter in token fails to match",
+			tmpl:   "ssh {user}@{host} {*rest}",
+			raw:    "ssh example.com",
+			wantOK: false,
+		},
+		{
+			name:   "wrong literal fails to match",
+			tmpl:   "git {subcmd} {*args}",
+			raw:    "docker ps",
+			wantOK: false,
+		},
+		{
+			name:   "too few tokens fails to match",
+			tmpl:   "git {subcmd} {extra}",
+			raw:    "git status",
+			wantOK: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl, err := Compile(tt.tmpl)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, ok := tmpl.Match(entry(tt.raw))
+			if ok != tt.wantOK {
+				t.Fatalf("Match() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if ok && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Match() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		tmpl string
+	}{
+		{"empty template", ""},
+		{"empty field name", "git {}"},
+		{"unterminated field", "git {subcmd"},
+		{"rest capture not final", "git {*args} {subcmd}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Compile(tt.tmpl); err == nil {
+				t.Errorf("Compile(%q) returned nil error, want error", tt.tmpl)
+			}
+		})
+	}
+}
+
+func TestBuiltins(t *testing.T) {
+	for name, tmpl := range Builtins {
+		if tmpl == nil {
+			t.Errorf("Builtins[%q] is nil", name)
+		}
+	}
+
+	fields, ok := Builtins["kubectl"].Match(entry("kubectl get pods -n default"))
+	if !ok {
+		t.Fatal("expected kubectl builtin to match")
+	}
+	if fields["subcmd"] != "get" {
+		t.Errorf("subcmd = %q, want \"get\"", fields["subcmd"])
+	}
+}
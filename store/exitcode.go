@@ -0,0 +1,61 @@
+package store
+
+import (
+	"bufio"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ParseExitCodeSidecar reads a sidecar file pairing history entries with
+// their exit codes, one line per command in the same order the precmd hook
+// that wrote it saw them run: "<unix timestamp>\t<exit code>" per line,
+// alongside the history file, for shells whose EXTENDED_HISTORY format
+// doesn't carry a return code. The timestamp field is only validated, not
+// used as a lookup key: two commands in the same one-second bucket would
+// collide on it, so the returned codes are indexed by line position instead
+// and meant to be matched against Entry.Seq via ApplyExitCodes.
+func ParseExitCodeSidecar(path string) ([]int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var codes []int
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		if _, err := strconv.ParseInt(fields[0], 10, 64); err != nil {
+			continue
+		}
+		code, err := strconv.Atoi(fields[1])
+		if err != nil {
+			continue
+		}
+		codes = append(codes, code)
+	}
+
+	return codes, scanner.Err()
+}
+
+// ApplyExitCodes fills in ExitCode on entries using the line-ordered codes
+// produced by ParseExitCodeSidecar, matching each entry to the sidecar line
+// at the same position (entries[i].Seq) rather than by timestamp, so two
+// entries sharing a one-second timestamp bucket get their own exit codes
+// instead of one colliding into the other. Entries whose Seq falls outside
+// the sidecar are left untouched.
+func ApplyExitCodes(entries []Entry, codes []int) {
+	for i := range entries {
+		if seq := entries[i].Seq; seq >= 0 && seq < len(codes) {
+			entries[i].ExitCode = codes[seq]
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package store
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseExitCodeSidecar(t *testing.T) {
+	path := writeTempFile(t, "sidecar", "1690000000\t0\n1690000000\t1\n\n1690000020\t2\n")
+
+	codes, err := ParseExitCodeSidecar(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []int{0, 1, 2}
+	if !reflect.DeepEqual(codes, want) {
+		t.Errorf("codes = %v, want %v", codes, want)
+	}
+}
+
+func TestParseExitCodeSidecarSkipsMalformedLines(t *testing.T) {
+	path := writeTempFile(t, "sidecar", "not-a-timestamp\t0\n1690000000\tnot-a-code\n1690000000 1\n1690000010\t1\n")
+
+	codes, err := ParseExitCodeSidecar(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []int{1, 1}
+	if !reflect.DeepEqual(codes, want) {
+		t.Errorf("codes = %v, want %v", codes, want)
+	}
+}
+
+func TestApplyExitCodesDisambiguatesSameSecondEntries(t *testing.T) {
+	// Two entries sharing a timestamp (the ls/cd-back-to-back case) must get
+	// their own exit codes from the sidecar, correlated by Seq rather than
+	// by colliding on the shared timestamp.
+	entries := []Entry{
+		{Timestamp: time.Unix(1690000000, 0), Parsed: ParsedCommand{Raw: "ls"}, Seq: 0},
+		{Timestamp: time.Unix(1690000000, 0), Parsed: ParsedCommand{Raw: "ls /nope"}, Seq: 1},
+	}
+	codes := []int{0, 1}
+
+	ApplyExitCodes(entries, codes)
+
+	if entries[0].ExitCode != 0 {
+		t.Errorf("entries[0].ExitCode = %d, want 0", entries[0].ExitCode)
+	}
+	if entries[1].ExitCode != 1 {
+		t.Errorf("entries[1].ExitCode = %d, want 1", entries[1].ExitCode)
+	}
+}
+
+func TestApplyExitCodesLeavesOutOfRangeEntriesUntouched(t *testing.T) {
+	entries := []Entry{
+		{Parsed: ParsedCommand{Raw: "ls"}, Seq: 5, ExitCode: 7},
+	}
+	ApplyExitCodes(entries, []int{0, 1})
+
+	if entries[0].ExitCode != 7 {
+		t.Errorf("ExitCode = %d, want unchanged 7", entries[0].ExitCode)
+	}
+}
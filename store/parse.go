@@ -0,0 +1,253 @@
+package store
+
+import (
+	"bufio"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// ParsedCommand holds a split command and its arguments.
+type ParsedCommand struct {
+	Raw  string   // original full command string
+	Cmd  string   // the base command (argv[0])
+	Args []string // arguments (argv[1:])
+}
+
+// Entry represents a single history entry, whether freshly parsed from a
+// shell history file or read back out of the store.
+type Entry struct {
+	ID        int64
+	Timestamp time.Time
+	Duration  int
+	Cwd       string
+	ExitCode  int
+	Host      string
+	Parsed    ParsedCommand
+
+	// Seq is the entry's position within its source file (0-based). It
+	// exists to disambiguate two genuinely separate invocations of the same
+	// command that land in the same one-second timestamp bucket from a
+	// duplicate line seen again on a repeat ingest; see Store.Ingest.
+	Seq int
+}
+
+// shellSplit splits a shell command string respecting quotes and escapes.
+// Handles: single quotes, double quotes, backslash escapes, and unquoted tokens.
+func shellSplit(s string) []string {
+	var tokens []string
+	var current strings.Builder
+	inSingle := false
+	inDouble := false
+	i := 0
+
+	for i < len(s) {
+		c := rune(s[i])
+
+		switch {
+		case inSingle:
+			if c == '\'' {
+				inSingle = false
+			} else {
+				current.WriteRune(c)
+			}
+			i++
+
+		case inDouble:
+			if c == '\\' && i+1 < len(s) {
+				next := rune(s[i+1])
+				// Only these chars are escapable inside double quotes
+				if next == '"' || next == '\\' || next == '$' || next == '`' || next == '\n' {
+					current.WriteRune(next)
+					i += 2
+				} else {
+					current.WriteRune(c)
+					i++
+				}
+			} else if c == '"' {
+				inDouble = false
+				i++
+			} else {
+				current.WriteRune(c)
+				i++
+			}
+
+		case c == '\\' && i+1 < len(s):
+			// Backslash escape outside quotes
+			current.WriteRune(rune(s[i+1]))
+			i += 2
+
+		case c == '\'':
+			inSingle = true
+			i++
+
+		case c == '"':
+			inDouble = true
+			i++
+
+		case unicode.IsSpace(c):
+			if current.Len() > 0 {
+				tokens = append(tokens, current.String())
+				current.Reset()
+			}
+			i++
+
+		default:
+			current.WriteRune(c)
+			i++
+		}
+	}
+
+	if current.Len() > 0 {
+		tokens = append(tokens, current.String())
+	}
+
+	return tokens
+}
+
+func parseCommand(raw string) ParsedCommand {
+	// Trim leading whitespace and common shell prefixes like `sudo`, `env VAR=val`, etc.
+	tokens := shellSplit(strings.TrimSpace(raw))
+	if len(tokens) == 0 {
+		return ParsedCommand{Raw: raw}
+	}
+
+	// Skip past leading env var assignments (e.g. FOO=bar cmd args)
+	start := 0
+	for start < len(tokens) && strings.Contains(tokens[start], "=") && !strings.HasPrefix(tokens[start], "-") {
+		start++
+	}
+	if start >= len(tokens) {
+		// Entire command was env vars
+		return ParsedCommand{Raw: raw, Cmd: tokens[0]}
+	}
+
+	return ParsedCommand{
+		Raw:  raw,
+		Cmd:  tokens[start],
+		Args: tokens[start+1:],
+	}
+}
+
+// ParseZshHistory reads a zsh history file, understanding both the plain
+// format (one command per line) and the EXTENDED_HISTORY format
+// (": <timestamp>:<duration>;<command>").
+func ParseZshHistory(path string) ([]Entry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 1024*1024), 1024*1024)
+
+	var currentCmd strings.Builder
+
+	flushEntry := func(line string) {
+		var raw string
+
+		if strings.HasPrefix(line, ": ") {
+			parts := strings.SplitN(line, ";", 2)
+			if len(parts) == 2 {
+				meta := strings.TrimPrefix(parts[0], ": ")
+				metaParts := strings.SplitN(meta, ":", 2)
+				if len(metaParts) == 2 {
+					ts, err1 := strconv.ParseInt(strings.TrimSpace(metaParts[0]), 10, 64)
+					dur, err2 := strconv.Atoi(strings.TrimSpace(metaParts[1]))
+					if err1 == nil && err2 == nil {
+						raw = parts[1]
+						entries = append(entries, Entry{
+							Timestamp: time.Unix(ts, 0),
+							Duration:  dur,
+							Parsed:    parseCommand(raw),
+						})
+						return
+					}
+				}
+			}
+		}
+
+		raw = line
+		if raw != "" {
+			entries = append(entries, Entry{Parsed: parseCommand(raw)})
+		}
+	}
+
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if strings.HasSuffix(line, "\\") {
+			currentCmd.WriteString(strings.TrimSuffix(line, "\\"))
+			currentCmd.WriteString("\n")
+			continue
+		}
+
+		if currentCmd.Len() > 0 {
+			currentCmd.WriteString(line)
+			flushEntry(currentCmd.String())
+			currentCmd.Reset()
+		} else {
+			flushEntry(line)
+		}
+	}
+
+	if currentCmd.Len() > 0 {
+		flushEntry(currentCmd.String())
+	}
+
+	assignSeq(entries)
+	return entries, scanner.Err()
+}
+
+// assignSeq stamps each entry with its position in the source file, so that
+// two entries sharing a (timestamp, raw) pair can still be told apart. See
+// the Entry.Seq doc comment for why this matters.
+func assignSeq(entries []Entry) {
+	for i := range entries {
+		entries[i].Seq = i
+	}
+}
+
+// ParseBashHistory reads a plain bash history file. Bash only timestamps
+// entries when HISTTIMEFORMAT is set, in which case a "#<epoch>" comment
+// line precedes the command it describes.
+func ParseBashHistory(path string) ([]Entry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []Entry
+	var pendingTS *int64
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 1024*1024), 1024*1024)
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "#") {
+			if ts, err := strconv.ParseInt(strings.TrimPrefix(line, "#"), 10, 64); err == nil {
+				pendingTS = &ts
+				continue
+			}
+		}
+		if line == "" {
+			continue
+		}
+
+		entry := Entry{Parsed: parseCommand(line)}
+		if pendingTS != nil {
+			entry.Timestamp = time.Unix(*pendingTS, 0)
+			pendingTS = nil
+		}
+		entries = append(entries, entry)
+	}
+
+	assignSeq(entries)
+	return entries, scanner.Err()
+}
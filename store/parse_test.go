@@ -0,0 +1,152 @@
+package store
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestShellSplit(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want []string
+	}{
+		{"simple", "ls -la", []string{"ls", "-la"}},
+		{"single quotes", `echo 'hello world'`, []string{"echo", "hello world"}},
+		{"double quotes", `echo "hello world"`, []string{"echo", "hello world"}},
+		{"backslash escape outside quotes", `echo foo\ bar`, []string{"echo", "foo bar"}},
+		{"double quote escapes", `echo "a\"b"`, []string{"echo", `a"b`}},
+		{"extra whitespace", "  git   status  ", []string{"git", "status"}},
+		{"empty", "", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := shellSplit(tt.in)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("shellSplit(%q) = %#v, want %#v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseCommand(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+		want ParsedCommand
+	}{
+		{
+			name: "plain",
+			raw:  "git commit -m foo",
+			want: ParsedCommand{Raw: "git commit -m foo", Cmd: "git", Args: []string{"commit", "-m", "foo"}},
+		},
+		{
+			name: "leading env vars skipped",
+			raw:  "FOO=bar BAZ=qux git status",
+			want: ParsedCommand{Raw: "FOO=bar BAZ=qux git status", Cmd: "git", Args: []string{"status"}},
+		},
+		{
+			name: "entirely env vars",
+			raw:  "FOO=bar",
+			want: ParsedCommand{Raw: "FOO=bar", Cmd: "FOO=bar"},
+		},
+		{
+			name: "empty",
+			raw:  "",
+			want: ParsedCommand{Raw: ""},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseCommand(tt.raw)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseCommand(%q) = %#v, want %#v", tt.raw, got, tt.want)
+			}
+		})
+	}
+}
+
+func writeTempFile(t *testing.T, name, contents string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestParseZshHistoryExtended(t *testing.T) {
+	path := writeTempFile(t, ".zsh_history", ": 1690000000:3;git status\n: 1690000010:0;ls -la\n")
+
+	entries, err := ParseZshHistory(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries, want 2", len(entries))
+	}
+
+	if !entries[0].Timestamp.Equal(time.Unix(1690000000, 0)) || entries[0].Duration != 3 || entries[0].Parsed.Cmd != "git" {
+		t.Errorf("entries[0] = %#v", entries[0])
+	}
+	if !entries[1].Timestamp.Equal(time.Unix(1690000010, 0)) || entries[1].Parsed.Cmd != "ls" {
+		t.Errorf("entries[1] = %#v", entries[1])
+	}
+	if entries[0].Seq != 0 || entries[1].Seq != 1 {
+		t.Errorf("Seq not assigned in file order: %d, %d", entries[0].Seq, entries[1].Seq)
+	}
+}
+
+func TestParseZshHistoryPlain(t *testing.T) {
+	path := writeTempFile(t, "plain_history", "git status\nls -la\n")
+
+	entries, err := ParseZshHistory(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries, want 2", len(entries))
+	}
+	if entries[0].Parsed.Raw != "git status" || entries[1].Parsed.Raw != "ls -la" {
+		t.Errorf("unexpected parsed raw lines: %#v", entries)
+	}
+}
+
+func TestParseZshHistoryLineContinuation(t *testing.T) {
+	path := writeTempFile(t, ".zsh_history", ": 1690000000:1;echo foo\\\nbar\n")
+
+	entries, err := ParseZshHistory(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("got %d entries, want 1", len(entries))
+	}
+	want := "echo foo\nbar"
+	if entries[0].Parsed.Raw != want {
+		t.Errorf("Raw = %q, want %q", entries[0].Parsed.Raw, want)
+	}
+}
+
+func TestParseBashHistory(t *testing.T) {
+	path := writeTempFile(t, ".bash_history", "#1690000000\ngit status\nls -la\n")
+
+	entries, err := ParseBashHistory(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries, want 2", len(entries))
+	}
+	if !entries[0].Timestamp.Equal(time.Unix(1690000000, 0)) {
+		t.Errorf("entries[0].Timestamp = %v, want timestamped from preceding # line", entries[0].Timestamp)
+	}
+	if !entries[1].Timestamp.IsZero() {
+		t.Errorf("entries[1].Timestamp = %v, want zero (no preceding # line)", entries[1].Timestamp)
+	}
+}
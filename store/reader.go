@@ -0,0 +1,222 @@
+package store
+
+import (
+	"bufio"
+	"bytes"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Format identifies a shell (or tool) history file format.
+type Format string
+
+const (
+	FormatZsh   Format = "zsh"
+	FormatBash  Format = "bash"
+	FormatFish  Format = "fish"
+	FormatAtuin Format = "atuin"
+)
+
+// HistoryReader parses a history file of some format into entries ready for
+// Store.Ingest. shellSplit/parseCommand are shared across all
+// implementations so argv splitting stays consistent regardless of which
+// shell produced the file.
+type HistoryReader interface {
+	Read(path string) ([]Entry, error)
+}
+
+type zshReader struct{}
+
+func (zshReader) Read(path string) ([]Entry, error) { return ParseZshHistory(path) }
+
+type bashReader struct{}
+
+func (bashReader) Read(path string) ([]Entry, error) { return ParseBashHistory(path) }
+
+type fishReader struct{}
+
+func (fishReader) Read(path string) ([]Entry, error) { return ParseFishHistory(path) }
+
+type atuinReader struct{}
+
+func (atuinReader) Read(path string) ([]Entry, error) { return ParseAtuinHistory(path) }
+
+// ReaderFor returns the HistoryReader implementation for format.
+func ReaderFor(format Format) (HistoryReader, error) {
+	switch format {
+	case FormatZsh:
+		return zshReader{}, nil
+	case FormatBash:
+		return bashReader{}, nil
+	case FormatFish:
+		return fishReader{}, nil
+	case FormatAtuin:
+		return atuinReader{}, nil
+	default:
+		return nil, fmt.Errorf("unknown history format %q", format)
+	}
+}
+
+// DetectFormat guesses a history file's format, first from its path and
+// falling back to sniffing its first line (or, for SQLite files, its file
+// header) when the path isn't recognized.
+func DetectFormat(path string) (Format, error) {
+	switch filepath.Base(path) {
+	case ".zsh_history":
+		return FormatZsh, nil
+	case ".bash_history":
+		return FormatBash, nil
+	case "fish_history":
+		return FormatFish, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	header := make([]byte, 16)
+	n, _ := f.Read(header)
+	if n >= 16 && bytes.Equal(header[:16], []byte("SQLite format 3\x00")) {
+		return FormatAtuin, nil
+	}
+
+	f.Seek(0, 0)
+	scanner := bufio.NewScanner(f)
+	if scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, ": "):
+			return FormatZsh, nil
+		case strings.HasPrefix(line, "- cmd: "):
+			return FormatFish, nil
+		}
+	}
+
+	// No recognizable marker; assume plain bash-style history.
+	return FormatBash, nil
+}
+
+// ParseFishHistory reads a fish_history file, whose entries look like:
+//
+//   - cmd: ls -la
+//     when: 1690000000
+//     paths:
+//   - foo
+//
+// The optional paths list (and any other metadata fish adds) is ignored.
+func ParseFishHistory(path string) ([]Entry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []Entry
+	var pending *Entry
+
+	flush := func() {
+		if pending != nil {
+			entries = append(entries, *pending)
+			pending = nil
+		}
+	}
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 1024*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "- cmd: "):
+			flush()
+			raw := fishUnescape(strings.TrimPrefix(line, "- cmd: "))
+			e := Entry{Parsed: parseCommand(raw)}
+			pending = &e
+		case strings.HasPrefix(line, "  when: "):
+			if pending == nil {
+				continue
+			}
+			ts, err := strconv.ParseInt(strings.TrimPrefix(line, "  when: "), 10, 64)
+			if err == nil {
+				pending.Timestamp = time.Unix(ts, 0)
+			}
+		}
+		// "  paths:" and its "    - ..." continuation lines are metadata we
+		// don't track and fall through here untouched.
+	}
+	flush()
+
+	assignSeq(entries)
+	return entries, scanner.Err()
+}
+
+func fishUnescape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			switch s[i+1] {
+			case 'n':
+				b.WriteByte('\n')
+				i++
+				continue
+			case '\\':
+				b.WriteByte('\\')
+				i++
+				continue
+			}
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// ParseAtuinHistory imports entries from an atuin SQLite history database.
+// Atuin stores timestamps and durations in nanoseconds, so both are scaled
+// down to the seconds/whole-number granularity the rest of this package
+// uses.
+func ParseAtuinHistory(path string) ([]Entry, error) {
+	db, err := sql.Open("sqlite3", "file:"+path+"?mode=ro")
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	rows, err := db.Query(`SELECT timestamp, duration, exit, command, cwd, hostname FROM history`)
+	if err != nil {
+		return nil, fmt.Errorf("querying atuin history: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []Entry
+	for rows.Next() {
+		var tsNanos, durNanos int64
+		var exitCode int
+		var command, cwd, hostname string
+		if err := rows.Scan(&tsNanos, &durNanos, &exitCode, &command, &cwd, &hostname); err != nil {
+			return nil, err
+		}
+
+		host := hostname
+		if idx := strings.IndexByte(host, ':'); idx >= 0 {
+			host = host[:idx]
+		}
+
+		entries = append(entries, Entry{
+			Timestamp: time.Unix(0, tsNanos),
+			Duration:  int(durNanos / 1e9),
+			Cwd:       cwd,
+			ExitCode:  exitCode,
+			Host:      host,
+			Parsed:    parseCommand(command),
+		})
+	}
+
+	assignSeq(entries)
+	return entries, rows.Err()
+}
@@ -0,0 +1,89 @@
+package store
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDetectFormat(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+		body string
+		want Format
+	}{
+		{"zsh by filename", ".zsh_history", "", FormatZsh},
+		{"bash by filename", ".bash_history", "", FormatBash},
+		{"fish by filename", "fish_history", "", FormatFish},
+		{"zsh by content", "unusual_name", ": 1690000000:0;ls\n", FormatZsh},
+		{"fish by content", "unusual_name", "- cmd: ls\n  when: 1690000000\n", FormatFish},
+		{"falls back to bash", "unusual_name", "ls -la\n", FormatBash},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeTempFile(t, tt.path, tt.body)
+			got, err := DetectFormat(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("DetectFormat(%q) = %q, want %q", tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReaderFor(t *testing.T) {
+	for _, f := range []Format{FormatZsh, FormatBash, FormatFish, FormatAtuin} {
+		if _, err := ReaderFor(f); err != nil {
+			t.Errorf("ReaderFor(%q) returned error: %v", f, err)
+		}
+	}
+	if _, err := ReaderFor(Format("nonsense")); err == nil {
+		t.Error("ReaderFor(\"nonsense\") returned nil error, want error for unknown format")
+	}
+}
+
+func TestParseFishHistory(t *testing.T) {
+	path := writeTempFile(t, "fish_history", strings.Join([]string{
+		"- cmd: git status",
+		"  when: 1690000000",
+		"- cmd: ls -la",
+		"  when: 1690000010",
+		"  paths:",
+		"    - foo",
+		"",
+	}, "\n"))
+
+	entries, err := ParseFishHistory(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries, want 2", len(entries))
+	}
+	if entries[0].Parsed.Cmd != "git" || !entries[0].Timestamp.Equal(time.Unix(1690000000, 0)) {
+		t.Errorf("entries[0] = %#v", entries[0])
+	}
+	if entries[1].Parsed.Raw != "ls -la" || !entries[1].Timestamp.Equal(time.Unix(1690000010, 0)) {
+		t.Errorf("entries[1] = %#v", entries[1])
+	}
+}
+
+func TestParseFishHistoryUnescapesCmd(t *testing.T) {
+	path := writeTempFile(t, "fish_history", `- cmd: echo foo\nbar`+"\n  when: 1690000000\n")
+
+	entries, err := ParseFishHistory(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("got %d entries, want 1", len(entries))
+	}
+	want := "echo foo\nbar"
+	if entries[0].Parsed.Raw != want {
+		t.Errorf("Raw = %q, want %q", entries[0].Parsed.Raw, want)
+	}
+}
@@ -0,0 +1,215 @@
+// Package store persists parsed shell history entries to a SQLite database
+// and provides a search API over them.
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"regexp"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+const schema = `
+CREATE TABLE IF NOT EXISTS history (
+	id        INTEGER PRIMARY KEY AUTOINCREMENT,
+	timestamp INTEGER NOT NULL,
+	duration  INTEGER NOT NULL DEFAULT 0,
+	cwd       TEXT NOT NULL DEFAULT '',
+	exit_code INTEGER NOT NULL DEFAULT 0,
+	host      TEXT NOT NULL DEFAULT '',
+	cmd       TEXT NOT NULL,
+	raw       TEXT NOT NULL,
+	seq       INTEGER NOT NULL DEFAULT 0,
+	UNIQUE(timestamp, raw, seq)
+);
+CREATE INDEX IF NOT EXISTS idx_history_cmd ON history(cmd);
+CREATE INDEX IF NOT EXISTS idx_history_timestamp ON history(timestamp);
+`
+
+// Store wraps a SQLite-backed history database.
+type Store struct {
+	db *sql.DB
+}
+
+// Open opens (creating if necessary) the SQLite database at path and
+// ensures its schema is up to date.
+func Open(path string) (*Store, error) {
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("migrating schema: %w", err)
+	}
+	return &Store{db: db}, nil
+}
+
+// Close closes the underlying database handle.
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Ingest inserts entries into the store, skipping any (timestamp, raw, seq)
+// triple that's already present so repeated ingests of the same history file
+// are idempotent. Seq is the entry's position within the source file, which
+// disambiguates two genuinely separate invocations of the same command in
+// the same second (common for fast commands like `ls` or `cd` run back to
+// back) from a duplicate line seen again on a repeat ingest. It returns the
+// number of rows actually inserted.
+func (s *Store) Ingest(entries []Entry) (int, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`
+		INSERT OR IGNORE INTO history (timestamp, duration, cwd, exit_code, host, cmd, raw, seq)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+
+	inserted := 0
+	for _, e := range entries {
+		res, err := stmt.Exec(e.Timestamp.Unix(), e.Duration, e.Cwd, e.ExitCode, e.Host, e.Parsed.Cmd, e.Parsed.Raw, e.Seq)
+		if err != nil {
+			return inserted, err
+		}
+		if n, _ := res.RowsAffected(); n > 0 {
+			inserted++
+		}
+	}
+
+	return inserted, tx.Commit()
+}
+
+// MatchMode selects how SearchOptions.Query is interpreted.
+type MatchMode int
+
+const (
+	// MatchSubstring matches Query anywhere in the raw command line.
+	MatchSubstring MatchMode = iota
+	// MatchPrefix matches Query against the start of the base command.
+	MatchPrefix
+	// MatchRegex matches Query as a regular expression against the raw
+	// command line.
+	MatchRegex
+)
+
+// SearchOptions filters the rows returned by Store.Search.
+type SearchOptions struct {
+	Query string
+	Mode  MatchMode
+
+	Since time.Time // zero means no lower bound
+	Until time.Time // zero means no upper bound
+
+	Cwd string // if non-empty, restrict to entries recorded in this directory
+
+	OnlyFailed  bool
+	OnlySuccess bool
+
+	Limit int // 0 means unlimited
+}
+
+// Search returns history entries matching opts, most recent first.
+//
+// Substring and prefix queries are pushed down to SQL; regex queries are
+// evaluated in Go since the sqlite3 driver has no REGEXP function wired up,
+// so the Limit clause is applied after regex filtering rather than in SQL.
+func (s *Store) Search(opts SearchOptions) ([]Entry, error) {
+	var re *regexp.Regexp
+	q := "SELECT id, timestamp, duration, cwd, exit_code, host, cmd, raw, seq FROM history WHERE 1=1"
+	var args []any
+
+	switch opts.Mode {
+	case MatchPrefix:
+		if opts.Query != "" {
+			q += " AND cmd LIKE ? ESCAPE '\\'"
+			args = append(args, likeEscape(opts.Query)+"%")
+		}
+	case MatchRegex:
+		if opts.Query != "" {
+			var err error
+			re, err = regexp.Compile(opts.Query)
+			if err != nil {
+				return nil, fmt.Errorf("compiling regex %q: %w", opts.Query, err)
+			}
+		}
+	default: // MatchSubstring
+		if opts.Query != "" {
+			q += " AND raw LIKE ? ESCAPE '\\'"
+			args = append(args, "%"+likeEscape(opts.Query)+"%")
+		}
+	}
+
+	if !opts.Since.IsZero() {
+		q += " AND timestamp >= ?"
+		args = append(args, opts.Since.Unix())
+	}
+	if !opts.Until.IsZero() {
+		q += " AND timestamp <= ?"
+		args = append(args, opts.Until.Unix())
+	}
+	if opts.Cwd != "" {
+		q += " AND cwd = ?"
+		args = append(args, opts.Cwd)
+	}
+	if opts.OnlyFailed {
+		q += " AND exit_code != 0"
+	}
+	if opts.OnlySuccess {
+		q += " AND exit_code = 0"
+	}
+
+	q += " ORDER BY timestamp DESC"
+	if re == nil && opts.Limit > 0 {
+		q += " LIMIT ?"
+		args = append(args, opts.Limit)
+	}
+
+	rows, err := s.db.Query(q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []Entry
+	for rows.Next() {
+		var e Entry
+		var ts int64
+		if err := rows.Scan(&e.ID, &ts, &e.Duration, &e.Cwd, &e.ExitCode, &e.Host, &e.Parsed.Cmd, &e.Parsed.Raw, &e.Seq); err != nil {
+			return nil, err
+		}
+		e.Timestamp = time.Unix(ts, 0)
+		e.Parsed = parseCommand(e.Parsed.Raw)
+
+		if re != nil && !re.MatchString(e.Parsed.Raw) {
+			continue
+		}
+		entries = append(entries, e)
+
+		if re != nil && opts.Limit > 0 && len(entries) >= opts.Limit {
+			break
+		}
+	}
+
+	return entries, rows.Err()
+}
+
+func likeEscape(s string) string {
+	r := make([]rune, 0, len(s))
+	for _, c := range s {
+		if c == '%' || c == '_' || c == '\\' {
+			r = append(r, '\\')
+		}
+		r = append(r, c)
+	}
+	return string(r)
+}
@@ -0,0 +1,159 @@
+package store
+
+import (
+	"testing"
+	"time"
+)
+
+func openTestStore(t *testing.T) *Store {
+	t.Helper()
+	s, err := Open(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func TestIngestIdempotent(t *testing.T) {
+	s := openTestStore(t)
+
+	entries := []Entry{
+		{Timestamp: time.Unix(1690000000, 0), Parsed: ParsedCommand{Raw: "git status", Cmd: "git"}, Seq: 0},
+		{Timestamp: time.Unix(1690000010, 0), Parsed: ParsedCommand{Raw: "ls -la", Cmd: "ls"}, Seq: 1},
+	}
+
+	n, err := s.Ingest(entries)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Fatalf("first ingest inserted %d rows, want 2", n)
+	}
+
+	n, err = s.Ingest(entries)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 0 {
+		t.Fatalf("repeat ingest inserted %d rows, want 0", n)
+	}
+
+	got, err := s.Search(SearchOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("Search returned %d rows, want 2", len(got))
+	}
+}
+
+func TestIngestDistinguishesSameSecondRepeats(t *testing.T) {
+	s := openTestStore(t)
+
+	// Two genuinely separate invocations of the same command in the same
+	// one-second timestamp bucket must both survive ingest.
+	entries := []Entry{
+		{Timestamp: time.Unix(1690000000, 0), Parsed: ParsedCommand{Raw: "ls", Cmd: "ls"}, Seq: 0},
+		{Timestamp: time.Unix(1690000000, 0), Parsed: ParsedCommand{Raw: "ls", Cmd: "ls"}, Seq: 1},
+	}
+
+	n, err := s.Ingest(entries)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Fatalf("inserted %d rows, want 2 (same-second repeats should not collapse)", n)
+	}
+
+	got, err := s.Search(SearchOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("Search returned %d rows, want 2", len(got))
+	}
+}
+
+func TestSearch(t *testing.T) {
+	s := openTestStore(t)
+
+	entries := []Entry{
+		{Timestamp: time.Unix(1000, 0), Parsed: ParsedCommand{Raw: "git status", Cmd: "git"}, Cwd: "/home/e", ExitCode: 0, Seq: 0},
+		{Timestamp: time.Unix(2000, 0), Parsed: ParsedCommand{Raw: "git commit -m oops", Cmd: "git"}, Cwd: "/home/e", ExitCode: 1, Seq: 1},
+		{Timestamp: time.Unix(3000, 0), Parsed: ParsedCommand{Raw: "ls -la", Cmd: "ls"}, Cwd: "/tmp", ExitCode: 0, Seq: 2},
+	}
+	if _, err := s.Ingest(entries); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("substring", func(t *testing.T) {
+		got, err := s.Search(SearchOptions{Query: "commit"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != 1 || got[0].Parsed.Raw != "git commit -m oops" {
+			t.Errorf("got %#v", got)
+		}
+	})
+
+	t.Run("prefix", func(t *testing.T) {
+		got, err := s.Search(SearchOptions{Query: "gi", Mode: MatchPrefix})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != 2 {
+			t.Errorf("got %d entries, want 2", len(got))
+		}
+	})
+
+	t.Run("regex", func(t *testing.T) {
+		got, err := s.Search(SearchOptions{Query: `^ls\b`, Mode: MatchRegex})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != 1 || got[0].Parsed.Cmd != "ls" {
+			t.Errorf("got %#v", got)
+		}
+	})
+
+	t.Run("cwd", func(t *testing.T) {
+		got, err := s.Search(SearchOptions{Cwd: "/tmp"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != 1 || got[0].Parsed.Cmd != "ls" {
+			t.Errorf("got %#v", got)
+		}
+	})
+
+	t.Run("only failed", func(t *testing.T) {
+		got, err := s.Search(SearchOptions{OnlyFailed: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != 1 || got[0].ExitCode == 0 {
+			t.Errorf("got %#v", got)
+		}
+	})
+
+	t.Run("since/until", func(t *testing.T) {
+		got, err := s.Search(SearchOptions{Since: time.Unix(1500, 0), Until: time.Unix(2500, 0)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != 1 || got[0].Timestamp.Unix() != 2000 {
+			t.Errorf("got %#v", got)
+		}
+	})
+
+	t.Run("limit", func(t *testing.T) {
+		got, err := s.Search(SearchOptions{Limit: 1})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != 1 {
+			t.Errorf("got %d entries, want 1", len(got))
+		}
+	})
+}